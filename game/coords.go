@@ -0,0 +1,84 @@
+package game
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// A point on the board. X is the column and Y the row, both zero based and
+// counted from the top-left corner, which is the same orientation SGF uses.
+type Point struct {
+	X int
+	Y int
+}
+
+// GTP column letters. GTP skips I to avoid confusion with J, so the widest
+// board it can address is 25 columns.
+const gtpColumns = "ABCDEFGHJKLMNOPQRSTUVWXYZ"
+
+// SGF coordinate letters. Boards up to 26 use a-z, larger boards continue
+// with A-Z as KataGo does.
+const sgfColumns = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ"
+
+// Converts an SGF coordinate pair such as "dp" to a Point on a width by height
+// board. SGF has its origin in the top-left corner, the first letter is the
+// column and the second the row, so no flipping is needed.
+func SGFToPoint(s string, width, height int) (Point, error) {
+	if len(s) != 2 {
+		return Point{}, fmt.Errorf("invalid SGF coordinate %q", s)
+	}
+	x := strings.IndexByte(sgfColumns, s[0])
+	y := strings.IndexByte(sgfColumns, s[1])
+	if x < 0 || y < 0 {
+		return Point{}, fmt.Errorf("invalid SGF coordinate %q", s)
+	}
+	if x >= width || y >= height {
+		return Point{}, fmt.Errorf("SGF coordinate %q is off a %dx%d board", s, width, height)
+	}
+	return Point{X: x, Y: y}, nil
+}
+
+// Converts a Point to an SGF coordinate pair. Returns an empty string if the
+// point cannot be represented.
+func PointToSGF(p Point, width, height int) string {
+	if p.X < 0 || p.Y < 0 || p.X >= width || p.Y >= height || p.X >= len(sgfColumns) || p.Y >= len(sgfColumns) {
+		return ""
+	}
+	return string([]byte{sgfColumns[p.X], sgfColumns[p.Y]})
+}
+
+// Converts a GTP vertex such as "D4" to a Point on a width by height board. GTP
+// has its origin in the bottom-left corner with rows numbered from 1, so the
+// row is flipped using the board height. Column letters are case insensitive.
+func GTPToPoint(s string, width, height int) (Point, error) {
+	s = strings.ToUpper(strings.TrimSpace(s))
+	if len(s) < 2 {
+		return Point{}, fmt.Errorf("invalid GTP vertex %q", s)
+	}
+	x := strings.IndexByte(gtpColumns, s[0])
+	if x < 0 {
+		return Point{}, fmt.Errorf("invalid GTP vertex %q", s)
+	}
+	// Atoi accepts a sign, which is not valid in a vertex
+	if strings.Trim(s[1:], "0123456789") != "" {
+		return Point{}, fmt.Errorf("invalid GTP vertex %q", s)
+	}
+	row, err := strconv.Atoi(s[1:])
+	if err != nil {
+		return Point{}, fmt.Errorf("invalid GTP vertex %q", s)
+	}
+	if x >= width || row < 1 || row > height {
+		return Point{}, fmt.Errorf("GTP vertex %q is off a %dx%d board", s, width, height)
+	}
+	return Point{X: x, Y: height - row}, nil
+}
+
+// Converts a Point to a GTP vertex. Returns an empty string if the point cannot
+// be represented.
+func PointToGTP(p Point, width, height int) string {
+	if p.X < 0 || p.Y < 0 || p.X >= width || p.Y >= height || p.X >= len(gtpColumns) {
+		return ""
+	}
+	return string(gtpColumns[p.X]) + strconv.Itoa(height-p.Y)
+}
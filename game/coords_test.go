@@ -0,0 +1,51 @@
+package game
+
+import "testing"
+
+func TestSGFAndGTPAgree(t *testing.T) {
+	tests := []struct {
+		sgf string
+		gtp string
+		p   Point
+	}{
+		{"aa", "A19", Point{X: 0, Y: 0}},
+		{"dp", "D4", Point{X: 3, Y: 15}},
+		{"ss", "T1", Point{X: 18, Y: 18}},
+		{"jj", "K10", Point{X: 9, Y: 9}},
+	}
+	for _, tt := range tests {
+		fromSGF, err := SGFToPoint(tt.sgf, 19, 19)
+		if err != nil || fromSGF != tt.p {
+			t.Errorf("SGFToPoint(%q) = %v, %v, want %v", tt.sgf, fromSGF, err, tt.p)
+		}
+		fromGTP, err := GTPToPoint(tt.gtp, 19, 19)
+		if err != nil || fromGTP != tt.p {
+			t.Errorf("GTPToPoint(%q) = %v, %v, want %v", tt.gtp, fromGTP, err, tt.p)
+		}
+		if got := PointToSGF(tt.p, 19, 19); got != tt.sgf {
+			t.Errorf("PointToSGF(%v) = %q, want %q", tt.p, got, tt.sgf)
+		}
+		if got := PointToGTP(tt.p, 19, 19); got != tt.gtp {
+			t.Errorf("PointToGTP(%v) = %q, want %q", tt.p, got, tt.gtp)
+		}
+	}
+}
+
+func TestCoordsRejectOffBoard(t *testing.T) {
+	for _, s := range []string{"za", "at", "a", "a1"} {
+		if _, err := SGFToPoint(s, 19, 19); err == nil {
+			t.Errorf("SGFToPoint(%q) succeeded, want error", s)
+		}
+	}
+	for _, s := range []string{"Z19", "I4", "D20", "D0", "D+4", "D-4", "4"} {
+		if _, err := GTPToPoint(s, 19, 19); err == nil {
+			t.Errorf("GTPToPoint(%q) succeeded, want error", s)
+		}
+	}
+	if got := PointToSGF(Point{X: 19, Y: 0}, 19, 19); got != "" {
+		t.Errorf("PointToSGF off board = %q, want empty", got)
+	}
+	if got := PointToGTP(Point{X: 0, Y: 19}, 19, 19); got != "" {
+		t.Errorf("PointToGTP off board = %q, want empty", got)
+	}
+}
@@ -0,0 +1,65 @@
+package game
+
+import "testing"
+
+// The settings OGS documents for each ruleset it offers. ParseRules must
+// produce exactly these, so any drift in the presets fails here.
+func TestOGSPresets(t *testing.T) {
+	tests := []struct {
+		name string
+		want Rules
+	}{
+		{"japanese", Rules{
+			KoRule:             KO_SIMPLE,
+			ScoringRule:        SCORE_TERRITORY,
+			TaxRule:            TAX_SEKI,
+			MultiStoneSuicide:  false,
+			HasButton:          false,
+			WhiteHandicapBonus: WHB_ZERO,
+			FriendlyPassOk:     true,
+			Komi:               6.5,
+		}},
+		{"korean", Rules{
+			KoRule:             KO_SIMPLE,
+			ScoringRule:        SCORE_TERRITORY,
+			TaxRule:            TAX_SEKI,
+			MultiStoneSuicide:  false,
+			HasButton:          false,
+			WhiteHandicapBonus: WHB_ZERO,
+			FriendlyPassOk:     true,
+			Komi:               6.5,
+		}},
+		{"chinese-ogs", Rules{
+			KoRule:             KO_POSITIONAL,
+			ScoringRule:        SCORE_AREA,
+			TaxRule:            TAX_NONE,
+			MultiStoneSuicide:  false,
+			HasButton:          false,
+			WhiteHandicapBonus: WHB_N,
+			FriendlyPassOk:     true,
+			Komi:               7.5,
+		}},
+		{"aga", Rules{
+			KoRule:             KO_SITUATIONAL,
+			ScoringRule:        SCORE_AREA,
+			TaxRule:            TAX_NONE,
+			MultiStoneSuicide:  false,
+			HasButton:          false,
+			WhiteHandicapBonus: WHB_N_MINUS_ONE,
+			FriendlyPassOk:     true,
+			Komi:               7.5,
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, _, ok := ParseRulesNamed(tt.name)
+			if !ok {
+				t.Fatalf("%q matched no preset", tt.name)
+			}
+			got := new(Rules).ParseRules(tt.name)
+			if *got != tt.want {
+				t.Errorf("ParseRules(%q) = %v, want %v", tt.name, got, tt.want)
+			}
+		})
+	}
+}
@@ -5,6 +5,9 @@ import (
 	"errors"
 	"fmt"
 	"math"
+	"net/url"
+	"os"
+	"sort"
 	"strconv"
 	"strings"
 )
@@ -43,15 +46,25 @@ const (
 	MAX_USER_KOMI = 150.0
 )
 
+// Board sizes KataGo accepts. Default KataGo builds stop at MAX_BOARD_LEN;
+// builds with a larger compiled maximum go up to MAX_COMPILED_BOARD_LEN.
+const (
+	MIN_BOARD_LEN          = 2
+	MAX_BOARD_LEN          = 19
+	MAX_COMPILED_BOARD_LEN = 29
+)
+
+// JSON is read and written by UnmarshalJSON and MarshalJSON in KataGo's format;
+// the tags only document the key each field uses there.
 type Rules struct {
-	KoRule             int     `json:"ko"`                           // Ko rule to use
-	ScoringRule        int     `json:"scoring"`                      // Scoring rule to use
-	TaxRule            int     `json:"tax"`                          // Tax rule to use
-	WhiteHandicapBonus int     `json:"whiteHandicapBonus,omitempty"` // Handicap bonus for White
-	MultiStoneSuicide  bool    `json:"suicide"`                      // Allow multi-st
-	HasButton          bool    `json:"hasButton"`                    // Has button
-	FriendlyPassOk     bool    `json:"friendlyPassOk"`               // Friendly pass ok
-	Komi               float32 `json:"komi,omitempty"`               // Komi value
+	KoRule             int     `json:"ko"`                 // Ko rule to use
+	ScoringRule        int     `json:"scoring"`            // Scoring rule to use
+	TaxRule            int     `json:"tax"`                // Tax rule to use
+	WhiteHandicapBonus int     `json:"whiteHandicapBonus"` // Handicap bonus for White
+	MultiStoneSuicide  bool    `json:"suicide"`            // Allow multi-st
+	HasButton          bool    `json:"hasButton"`          // Has button
+	FriendlyPassOk     bool    `json:"friendlyPassOk"`     // Friendly pass ok
+	Komi               float32 `json:"komi"`               // Komi value
 
 }
 
@@ -109,6 +122,34 @@ func (r *Rules) EqualsIgnoringKomi(other *Rules) bool {
 		r.FriendlyPassOk == other.FriendlyPassOk
 }
 
+func (r *Rules) Equals(other *Rules) bool {
+	return r.EqualsIgnoringKomi(other) && r.Komi == other.Komi
+}
+
+// Like Equals, but komi only needs to agree within komiTol. Komi parsed from
+// different sources can differ in its last bits, which should not count as a
+// change of rules.
+func (r *Rules) EqualsWithin(other *Rules, komiTol float32) bool {
+	return r.EqualsIgnoringKomi(other) && float32(math.Abs(float64(r.Komi-other.Komi))) <= komiTol
+}
+
+// Checks if the rules count stones plus surrounded empty points.
+func (r *Rules) IsAreaScoring() bool {
+	return r.ScoringRule == SCORE_AREA
+}
+
+// Checks if the rules count surrounded empty points plus captures.
+func (r *Rules) IsTerritoryScoring() bool {
+	return r.ScoringRule == SCORE_TERRITORY
+}
+
+// Checks if filling a neutral point changes the score. Under area scoring the
+// stone played counts for its owner, under territory scoring dame are worth
+// nothing to either player.
+func (r *Rules) DameHaveValue() bool {
+	return r.IsAreaScoring()
+}
+
 // Checks if the final score of the game will result in an integer. This is possible
 // when provided komi does not have the traditional 0.5 added to it.
 func (r *Rules) GameResultWillBeInteger() bool {
@@ -116,8 +157,205 @@ func (r *Rules) GameResultWillBeInteger() bool {
 	return komiIsInteger != r.HasButton
 }
 
+// Returns the komi nearest the current one with which the game cannot end in a
+// draw, or the current komi if GameResultWillBeInteger already rules a draw
+// out. Half a point is added, or taken away instead if adding it would exceed
+// MAX_USER_KOMI. The rules themselves are not modified.
+func (r *Rules) AdjustKomiToAvoidDraw() float32 {
+	if !r.GameResultWillBeInteger() {
+		return r.Komi
+	}
+	if r.Komi+0.5 > MAX_USER_KOMI {
+		return r.Komi - 0.5
+	}
+	return r.Komi + 0.5
+}
+
+// Returns the conventional komi for the scoring rule. Area scoring uses 7.5,
+// or 7 when the button supplies the extra half point, territory scoring uses
+// KOMI_DEFAULT.
+func (r *Rules) DefaultKomi() float32 {
+	if r.IsAreaScoring() {
+		if r.HasButton {
+			return 7.0
+		}
+		return 7.5
+	}
+	return KOMI_DEFAULT
+}
+
+// Returns the komi values commonly offered for this ruleset, with DefaultKomi
+// first. Territory scoring also offers 5.5 as used under older Japanese and
+// Korean rules. Button games only offer 7, not 7.5: the button already adds the
+// half point, and 7.5 plus the button would make draws possible again.
+func (r *Rules) SuggestedKomi() []float32 {
+	if r.IsAreaScoring() {
+		if r.HasButton {
+			return []float32{7.0}
+		}
+		return []float32{7.5, 6.5}
+	}
+	return []float32{KOMI_DEFAULT, 5.5}
+}
+
+// Returns the conventional komi for a game with n handicap stones. Without a
+// handicap this is DefaultKomi. With one, komi is 0.5 under territory scoring
+// and under area scoring when White is compensated for the stones (WHB_N or
+// WHB_N_MINUS_ONE). Area scoring with WHB_ZERO leaves Black's extra stones
+// uncompensated, so komi grows by n-1 to match the AGA convention. Button
+// games take half a point less, as the button supplies it.
+func (r *Rules) HandicapGameKomi(n int) float32 {
+	if n <= 0 {
+		return r.DefaultKomi()
+	}
+	komi := float32(0.5)
+	if r.IsAreaScoring() && r.WhiteHandicapBonus == WHB_ZERO {
+		komi += float32(n - 1)
+	}
+	if r.HasButton {
+		komi -= 0.5
+	}
+	return komi
+}
+
+// Returns a copy of the rules with komi removed, for studying raw area or
+// territory counts. Scoring with these rules is the same as scoring with komi
+// 0; the method only exists to state that intent.
+func (r *Rules) WithoutKomi() Rules {
+	rules := *r
+	rules.Komi = 0
+	return rules
+}
+
+// Checks that komi lies within MIN_USER_KOMI and MAX_USER_KOMI inclusive. A
+// float32 represents every integer and half integer in this range exactly, so
+// even 150 plus a half point button carries no rounding error.
+func (r *Rules) KomiInBounds() bool {
+	return r.Komi >= MIN_USER_KOMI && r.Komi <= MAX_USER_KOMI
+}
+
 func komiIsIntOrHalfInt(komi float32) bool {
-	return !math.IsInf(float64(komi), 0) && komi*2 == float32(int(komi)*2)
+	return !math.IsInf(float64(komi), 0) && komi*2 == float32(int(komi*2))
+}
+
+func validateKomi(komi float32) error {
+	if !komiIsIntOrHalfInt(komi) {
+		return fmt.Errorf("komi %v is not an integer or half integer", komi)
+	}
+	if komi < MIN_USER_KOMI || komi > MAX_USER_KOMI {
+		return fmt.Errorf("komi %v is outside [%v, %v]", komi, MIN_USER_KOMI, MAX_USER_KOMI)
+	}
+	return nil
+}
+
+// Parses a komi value such as "7.5". Reverse komi may be written as a negative
+// value or, as OGS does, as a magnitude with a "rev" or "reverse" prefix, so
+// "rev 6.5" is -6.5. Komi must be an integer or half integer between
+// MIN_USER_KOMI and MAX_USER_KOMI.
+func ParseKomi(s string) (float32, error) {
+	s = strings.TrimSpace(s)
+	reverse := false
+	for _, prefix := range []string{"reverse", "rev"} {
+		if len(s) >= len(prefix) && strings.EqualFold(s[:len(prefix)], prefix) {
+			s = strings.TrimSpace(s[len(prefix):])
+			reverse = true
+			break
+		}
+	}
+	// ParseFloat also reads forms such as "0x1p3", "Inf" and "1e1", which are
+	// not komi, so only plain decimals are let through
+	digits := strings.TrimPrefix(strings.TrimPrefix(s, "-"), "+")
+	if len(digits) < len(s)-1 || strings.Trim(digits, "0123456789.") != "" || strings.Count(digits, ".") > 1 || strings.Trim(digits, ".") == "" {
+		return 0, errors.New("invalid Komi")
+	}
+	komi, err := strconv.ParseFloat(s, 32)
+	if err != nil {
+		return 0, errors.New("invalid Komi")
+	}
+	if reverse {
+		if digits != s {
+			return 0, errors.New("reverse komi must be given as an unsigned magnitude")
+		}
+		komi = -komi
+	}
+	// -0 is written back as "-0", so it is read as plain 0
+	if komi == 0 {
+		komi = 0
+	}
+	if err := validateKomi(float32(komi)); err != nil {
+		return 0, err
+	}
+	return float32(komi), nil
+}
+
+// Parses the value of an SGF KM property. Writers emit forms such as "7",
+// "7.5" or an empty value; an empty value means the ruleset's DefaultKomi.
+// Values that are not integers or half integers, such as the quarter points
+// some exporters write, are rejected.
+func (r *Rules) ParseSGFKomi(s string) (float32, error) {
+	if strings.TrimSpace(s) == "" {
+		return r.DefaultKomi(), nil
+	}
+	komi, err := ParseKomi(s)
+	if err != nil {
+		return 0, fmt.Errorf("KM[%s]: %w", s, err)
+	}
+	return komi, nil
+}
+
+// Checks that every rule holds a known value, that komi is valid, and that the
+// rules are consistent with each other. Every pairing of scoring and tax rule
+// is supported, matching KataGo:
+//
+//	            TAX_NONE   TAX_SEKI   TAX_ALL
+//	AREA        yes        yes        yes (stone scoring)
+//	TERRITORY   yes        yes        yes (ancient territory)
+//
+// The button is the one combination that is rejected outside area scoring, as
+// it only exists to make area scoring count like territory scoring.
+func (r *Rules) Validate() error {
+	if r.KoRule < 0 || r.KoRule >= len(koRuleStrings()) {
+		return errors.New("invalid Ko Rule")
+	}
+	if r.ScoringRule < 0 || r.ScoringRule >= len(scoringRuleStrings()) {
+		return errors.New("invalid Scoring Rule")
+	}
+	if r.TaxRule < 0 || r.TaxRule >= len(taxRuleStrings()) {
+		return errors.New("invalid Tax Rule")
+	}
+	if r.WhiteHandicapBonus < 0 || r.WhiteHandicapBonus >= len(whiteHandicapBonusStrings()) {
+		return errors.New("invalid White Handicap Bonus")
+	}
+	if err := validateKomi(r.Komi); err != nil {
+		return err
+	}
+	if r.HasButton && !r.IsAreaScoring() {
+		return fmt.Errorf("button is only supported with area scoring, not %s", writeScoringRule(r.ScoringRule))
+	}
+	return nil
+}
+
+// Checks that the rules are valid and that a width by height board is within
+// the sizes a default KataGo build accepts, up to MAX_BOARD_LEN. KataGo allows
+// rectangular boards under every ruleset, so the rules only need to pass
+// Validate. Use ValidateForBoardMaxLen for builds with a larger maximum.
+func (r *Rules) ValidateForBoard(width, height int) error {
+	return r.ValidateForBoardMaxLen(width, height, MAX_BOARD_LEN)
+}
+
+// Like ValidateForBoard, but for a KataGo build compiled with a maximum board
+// length of maxLen, which may be at most MAX_COMPILED_BOARD_LEN.
+func (r *Rules) ValidateForBoardMaxLen(width, height, maxLen int) error {
+	if maxLen < MIN_BOARD_LEN || maxLen > MAX_COMPILED_BOARD_LEN {
+		return fmt.Errorf("maximum board length %d is outside %d to %d", maxLen, MIN_BOARD_LEN, MAX_COMPILED_BOARD_LEN)
+	}
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	if width < MIN_BOARD_LEN || width > maxLen || height < MIN_BOARD_LEN || height > maxLen {
+		return fmt.Errorf("board size %dx%d is outside %d to %d", width, height, MIN_BOARD_LEN, maxLen)
+	}
+	return nil
 }
 
 func koRuleStrings() []string {
@@ -177,7 +415,7 @@ func parseTaxRule(s string) (int, error) {
 
 func parseWhiteHandicapBonus(s string) (int, error) {
 	switch s {
-	case "ZERO":
+	case "ZERO", "0":
 		return WHB_ZERO, nil
 	case "N":
 		return WHB_N, nil
@@ -256,29 +494,189 @@ func (r *Rules) ToStringNoKomi() string {
 	if r.HasButton {
 		sb.WriteString(", Has Button")
 	}
-	if r.WhiteHandicapBonus != WHB_ZERO {
-		sb.WriteString(", White Handicap Bonus: ")
-		sb.WriteString(writeWhiteHandicapBonus(r.WhiteHandicapBonus))
-	}
 	if r.FriendlyPassOk {
 		sb.WriteString(", Friendly Pass OK")
 	}
 	return sb.String()
 }
 
+// Formats komi in the shortest form that reads back exactly, such as "7.5" or
+// "7".
+func formatKomi(komi float32) string {
+	return strconv.FormatFloat(float64(komi), 'f', -1, 32)
+}
+
 func (r *Rules) ToString() string {
 	var sb strings.Builder
 	sb.WriteString(r.ToStringNoKomi())
 	sb.WriteString(", Komi: ")
-	sb.WriteString(strconv.FormatFloat(float64(r.Komi), 'f', -1, 32))
+	sb.WriteString(formatKomi(r.Komi))
 	return sb.String()
 }
 
-// TODO: Is this correct? This should achieve a similar thing as https://github.com/lightvector/KataGo/blob/4dfed3ebc9dd289f52c5cb81de45bfd40af8478d/cpp/game/rules.cpp#L233 but is untested
+// Implements fmt.Stringer so rules print readably with %v.
+func (r Rules) String() string {
+	return r.ToString()
+}
+
+// Original: https://github.com/lightvector/KataGo/blob/4dfed3ebc9dd289f52c5cb81de45bfd40af8478d/cpp/game/rules.cpp#L233
+// Serializes the rules in the format KataGo's analysis engine accepts for the
+// rules of a query. See MarshalJSON.
 func (r *Rules) ToJson() ([]byte, error) {
 	return json.Marshal(r)
 }
 
+// JSON layout of Rules as written by KataGo. KataGo builds the object with
+// nlohmann::json, which keeps keys sorted, so the fields are declared in sorted
+// order to produce the same bytes.
+type rulesJson struct {
+	FriendlyPassOk     bool        `json:"friendlyPassOk"`
+	HasButton          bool        `json:"hasButton"`
+	KoRule             string      `json:"ko"`
+	Komi               json.Number `json:"komi"`
+	ScoringRule        string      `json:"scoring"`
+	MultiStoneSuicide  bool        `json:"suicide"`
+	TaxRule            string      `json:"tax"`
+	WhiteHandicapBonus string      `json:"whiteHandicapBonus"`
+}
+
+// Writes the rules exactly as KataGo does: enums by name, the white handicap
+// bonus as "0", "N" or "N-1", and komi always with a decimal point (7.0 rather
+// than 7). Note that KataGo calls multi stone suicide "suicide" here too.
+func (r Rules) MarshalJSON() ([]byte, error) {
+	out := rulesJson{
+		FriendlyPassOk:     r.FriendlyPassOk,
+		HasButton:          r.HasButton,
+		KoRule:             writeKoRule(r.KoRule),
+		ScoringRule:        writeScoringRule(r.ScoringRule),
+		MultiStoneSuicide:  r.MultiStoneSuicide,
+		TaxRule:            writeTaxRule(r.TaxRule),
+		WhiteHandicapBonus: writeWhiteHandicapBonus(r.WhiteHandicapBonus),
+	}
+	if out.KoRule == "UNKNOWN" || out.ScoringRule == "UNKNOWN" || out.TaxRule == "UNKNOWN" || out.WhiteHandicapBonus == "UNKNOWN" {
+		return nil, errors.New("cannot serialize rules with an invalid enum value")
+	}
+	if r.WhiteHandicapBonus == WHB_ZERO {
+		out.WhiteHandicapBonus = "0"
+	}
+	komi := formatKomi(r.Komi)
+	if !strings.Contains(komi, ".") {
+		komi += ".0"
+	}
+	out.Komi = json.Number(komi)
+	return json.Marshal(out)
+}
+
+// Reads rules with FromJson, so both KataGo's JSON and numeric enums are
+// accepted. A JSON null leaves the rules unchanged, as encoding/json expects.
+func (r *Rules) UnmarshalJSON(data []byte) error {
+	if string(data) == "null" {
+		return nil
+	}
+	rules, err := r.FromJson(data)
+	if err != nil {
+		return err
+	}
+	*r = *rules
+	return nil
+}
+
+// Reads an enum value from JSON. The value is tried as a rule name first, then
+// as the raw numeric value older tools emit, which must lie in [0, count).
+func parseJsonEnum(raw json.RawMessage, parse func(string) (int, error), count int) (int, error) {
+	var name string
+	if err := json.Unmarshal(raw, &name); err == nil {
+		return parse(name)
+	}
+	var n int
+	if err := json.Unmarshal(raw, &n); err != nil {
+		return -1, fmt.Errorf("expected a string or integer, got %s", raw)
+	}
+	if n < 0 || n >= count {
+		return -1, fmt.Errorf("enum value %d out of range", n)
+	}
+	return n, nil
+}
+
+// Creates a Rules object from a JSON object such as the one written by ToJson.
+// Enum rules may be given by name ("POSITIONAL") or by numeric value (1), so
+// JSON from older tools is accepted as well. Keys that are not present keep
+// their TrompTaylorish values. A JSON null is rejected rather than read as
+// TrompTaylorish rules.
+func (r *Rules) FromJson(data []byte) (*Rules, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(data, &fields); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		return nil, errors.New("rules JSON must be an object")
+	}
+	rules := r.GetTrompTaylorish()
+	for k, raw := range fields {
+		var err error
+		switch k {
+		case "ko":
+			rules.KoRule, err = parseJsonEnum(raw, parseKoRule, len(koRuleStrings()))
+		case "scoring":
+			rules.ScoringRule, err = parseJsonEnum(raw, parseScoringRule, len(scoringRuleStrings()))
+		case "tax":
+			rules.TaxRule, err = parseJsonEnum(raw, parseTaxRule, len(taxRuleStrings()))
+		case "whiteHandicapBonus":
+			rules.WhiteHandicapBonus, err = parseJsonEnum(raw, parseWhiteHandicapBonus, len(whiteHandicapBonusStrings()))
+		case "suicide":
+			err = json.Unmarshal(raw, &rules.MultiStoneSuicide)
+		case "hasButton":
+			err = json.Unmarshal(raw, &rules.HasButton)
+		case "friendlyPassOk":
+			err = json.Unmarshal(raw, &rules.FriendlyPassOk)
+		case "komi":
+			err = json.Unmarshal(raw, &rules.Komi)
+			if err == nil {
+				err = validateKomi(rules.Komi)
+			}
+		default:
+			return nil, fmt.Errorf("%s is %w", k, ErrUnknownRuleKey)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", k, err)
+		}
+	}
+	return rules, nil
+}
+
+// Rewrites rules JSON from older versions into the format written by ToJson.
+// Older versions wrote enums as numbers and dropped komi when it was 0, so a
+// missing komi is read as 0 instead of the TrompTaylorish default. The name
+// "multiStoneSuicideLegal" is accepted in place of "suicide".
+func MigrateRulesJSON(old []byte) ([]byte, error) {
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(old, &fields); err != nil {
+		return nil, err
+	}
+	if fields == nil {
+		return nil, errors.New("rules JSON must be an object")
+	}
+	if v, ok := fields["multiStoneSuicideLegal"]; ok {
+		if _, ok := fields["suicide"]; ok {
+			return nil, errors.New("both suicide and multiStoneSuicideLegal are set")
+		}
+		fields["suicide"] = v
+		delete(fields, "multiStoneSuicideLegal")
+	}
+	if _, ok := fields["komi"]; !ok {
+		fields["komi"] = json.RawMessage("0")
+	}
+	normalized, err := json.Marshal(fields)
+	if err != nil {
+		return nil, err
+	}
+	rules, err := new(Rules).FromJson(normalized)
+	if err != nil {
+		return nil, err
+	}
+	return rules.ToJson()
+}
+
 func stringToBool(s string) (bool, error) {
 	if s == "true" || s == "True" {
 		return true, nil
@@ -289,6 +687,10 @@ func stringToBool(s string) (bool, error) {
 	return false, errors.New("input should be 'true' or 'false'")
 }
 
+// Returned, wrapped with the key, by UpdateRules and FromJson for a key they do
+// not know. Check for it with errors.Is.
+var ErrUnknownRuleKey = errors.New("not a valid rule key")
+
 // Method to update the rules of the game. This will update in place, as well as
 // return the updated rules. Note that this could fail silently if the returned
 // values are not verified.
@@ -300,8 +702,7 @@ func (r *Rules) UpdateRules(k, v string) (*Rules, error) {
 			return nil, err
 		}
 		r.KoRule = newVal
-	case "score":
-	case "scoring":
+	case "score", "scoring":
 		newVal, err := parseScoringRule(v)
 		if err != nil {
 			return nil, err
@@ -337,12 +738,274 @@ func (r *Rules) UpdateRules(k, v string) (*Rules, error) {
 			return nil, err
 		}
 		r.FriendlyPassOk = newVal
+	case "komi":
+		newVal, err := ParseKomi(v)
+		if err != nil {
+			return nil, err
+		}
+		r.Komi = newVal
 	default:
-		return nil, fmt.Errorf("%s is not a valid rule key", k)
+		return nil, fmt.Errorf("%s is %w", k, ErrUnknownRuleKey)
+	}
+	return r, nil
+}
+
+// Applies every key and value in m with UpdateRules, in sorted key order so
+// errors are reproducible. The error names the key that failed.
+func (r *Rules) UpdateRulesMap(m map[string]string) (*Rules, error) {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if _, err := r.UpdateRules(k, m[k]); err != nil {
+			return nil, fmt.Errorf("%s=%s: %w", k, m[k], err)
+		}
 	}
 	return r, nil
 }
 
+// Returns every rule as a string keyed by its UpdateRules key, for rendering
+// rules as key/value rows. This is the inverse of UpdateRulesMap.
+func (r *Rules) AsStringMap() map[string]string {
+	return map[string]string{
+		"ko":                 writeKoRule(r.KoRule),
+		"scoring":            writeScoringRule(r.ScoringRule),
+		"tax":                writeTaxRule(r.TaxRule),
+		"suicide":            strconv.FormatBool(r.MultiStoneSuicide),
+		"hasButton":          strconv.FormatBool(r.HasButton),
+		"whiteHandicapBonus": writeWhiteHandicapBonus(r.WhiteHandicapBonus),
+		"friendlyPassOk":     strconv.FormatBool(r.FriendlyPassOk),
+		"komi":               formatKomi(r.Komi),
+	}
+}
+
+// Like UpdateRules, but unknown keys are skipped instead of failing, so configs
+// written for newer versions still load. Returns whether the key was
+// recognized, and an error only when a recognized key has an invalid value.
+func (r *Rules) UpdateRulesLenient(k, v string) (bool, error) {
+	if _, err := r.UpdateRules(k, v); err != nil {
+		if errors.Is(err, ErrUnknownRuleKey) {
+			return false, nil
+		}
+		return true, err
+	}
+	return true, nil
+}
+
+// Creates a Rules object from URL query values such as
+// "ko=POSITIONAL&scoring=AREA&tax=NONE&komi=7.5". Starts from TrompTaylorish
+// rules and applies the values with UpdateRulesMap. A key given more than once
+// is rejected rather than silently using its first value.
+func ParseRulesQuery(values url.Values) (*Rules, error) {
+	m := make(map[string]string, len(values))
+	for k, vs := range values {
+		if len(vs) > 1 {
+			return nil, fmt.Errorf("%s is given %d times", k, len(vs))
+		}
+		m[k] = values.Get(k)
+	}
+	return new(Rules).GetTrompTaylorish().UpdateRulesMap(m)
+}
+
+// Creates a Rules object from a preset followed by overrides, such as
+// "chinese,komi=7.5,ko=POSITIONAL". The preset is resolved as in ParseRules,
+// except that an unknown preset is an error rather than Tromp-Taylor, then each
+// key=value override is applied in order with UpdateRules. The error names the
+// offending token.
+func ParseRulesDSL(s string) (*Rules, error) {
+	tokens := strings.Split(s, ",")
+	preset := strings.TrimSpace(tokens[0])
+	if preset == "" || strings.Contains(preset, "=") {
+		return nil, fmt.Errorf("rules %q must start with a preset name", s)
+	}
+	match := matchPreset(preset)
+	if match == nil {
+		return nil, fmt.Errorf("unknown preset %q", preset)
+	}
+	rules := match.rules
+	for _, token := range tokens[1:] {
+		k, v, ok := strings.Cut(strings.TrimSpace(token), "=")
+		if !ok {
+			return nil, fmt.Errorf("malformed override %q, expected key=value", token)
+		}
+		if _, err := rules.UpdateRules(strings.TrimSpace(k), strings.TrimSpace(v)); err != nil {
+			return nil, fmt.Errorf("override %q: %w", token, err)
+		}
+	}
+	return &rules, nil
+}
+
+// Environment variables read by RulesFromEnv and the UpdateRules key each one
+// sets.
+var rulesEnvOverrides = []struct {
+	env string
+	key string
+}{
+	{"KATAGOGO_KO", "ko"},
+	{"KATAGOGO_SCORING", "scoring"},
+	{"KATAGOGO_TAX", "tax"},
+	{"KATAGOGO_SUICIDE", "suicide"},
+	{"KATAGOGO_HAS_BUTTON", "hasButton"},
+	{"KATAGOGO_WHITE_HANDICAP_BONUS", "whiteHandicapBonus"},
+	{"KATAGOGO_FRIENDLY_PASS_OK", "friendlyPassOk"},
+	{"KATAGOGO_KOMI", "komi"},
+}
+
+// Creates a Rules object from the environment. KATAGOGO_RULES selects the base
+// ruleset, either as KataGo's compact encoding or as a preset name, and
+// defaults to TrompTaylorish rules when unset. Each field variable, such as
+// KATAGOGO_KO or KATAGOGO_KOMI, then overrides its rule, so field variables
+// always take precedence over KATAGOGO_RULES. Empty variables count as unset.
+// The combined rules must pass Validate.
+func RulesFromEnv() (*Rules, error) {
+	rules := new(Rules).GetTrompTaylorish()
+	if s := os.Getenv("KATAGOGO_RULES"); s != "" {
+		if compact, err := ParseCompactRules(s); err == nil {
+			rules = compact
+		} else if preset := matchPreset(s); preset != nil {
+			named := preset.rules
+			rules = &named
+		} else {
+			return nil, fmt.Errorf("KATAGOGO_RULES %q is neither a preset nor compact rules", s)
+		}
+	}
+	for _, o := range rulesEnvOverrides {
+		v := os.Getenv(o.env)
+		if v == "" {
+			continue
+		}
+		if _, err := rules.UpdateRules(o.key, v); err != nil {
+			return nil, fmt.Errorf("%s: %w", o.env, err)
+		}
+	}
+	if err := rules.Validate(); err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// Optional hook reporting which preset ParseRules matched, or that it fell back
+// to TrompTaylorish rules. Leave nil to disable logging.
+var ParseLogf func(format string, args ...any)
+
+func parseLogf(format string, args ...any) {
+	if ParseLogf != nil {
+		ParseLogf(format, args...)
+	}
+}
+
+// A named ruleset recognized by ParseRules.
+type rulesPreset struct {
+	name    string   // Name used for display
+	aliases []string // Normalized names that select the preset
+	rules   Rules
+}
+
+// Named rulesets in the order ParseRules checks them. RulesetName reports the
+// first preset whose rules match, so aliases of the same rules share an entry.
+var rulesPresets = []rulesPreset{
+	// KGS Japanese uses the same settings as Japanese. Where KGS differs is in
+	// how the end of the game is settled: dead stones are marked by agreement
+	// instead of by the Japanese rules' hypothetical play, and long cycles such
+	// as triple ko are not declared no result. Neither is a rule setting.
+	// Passing with dead stones on the board is fine, as OGS and KGS settle them
+	// in a removal phase after the game.
+	{"Japanese", []string{"japanese", "korean", "kgsjapanese", "japanesekgs"}, Rules{
+		KoRule:             KO_SIMPLE,
+		ScoringRule:        SCORE_TERRITORY,
+		TaxRule:            TAX_SEKI,
+		MultiStoneSuicide:  false,
+		HasButton:          false,
+		WhiteHandicapBonus: WHB_ZERO,
+		FriendlyPassOk:     true,
+		Komi:               6.5,
+	}},
+	{"Chinese", []string{"chinese"}, Rules{
+		KoRule:             KO_SIMPLE,
+		ScoringRule:        SCORE_AREA,
+		TaxRule:            TAX_NONE,
+		MultiStoneSuicide:  false,
+		HasButton:          false,
+		WhiteHandicapBonus: WHB_N,
+		FriendlyPassOk:     false,
+		Komi:               7.5,
+	}},
+	// OGS and KGS enforce positional superko for Chinese rules.
+	{"Chinese (OGS/KGS)", []string{"chineseogs", "chinesekgs"}, Rules{
+		KoRule:             KO_POSITIONAL,
+		ScoringRule:        SCORE_AREA,
+		TaxRule:            TAX_NONE,
+		MultiStoneSuicide:  false,
+		HasButton:          false,
+		WhiteHandicapBonus: WHB_N,
+		FriendlyPassOk:     true,
+		Komi:               7.5,
+	}},
+	{"Stone Scoring", []string{"ancientarea", "stonescoring"}, Rules{
+		KoRule:             KO_SIMPLE,
+		ScoringRule:        SCORE_AREA,
+		TaxRule:            TAX_ALL,
+		MultiStoneSuicide:  false,
+		HasButton:          false,
+		WhiteHandicapBonus: WHB_ZERO,
+		FriendlyPassOk:     true,
+		Komi:               7.5,
+	}},
+	{"Ancient Territory", []string{"ancientterritory"}, Rules{
+		KoRule:             KO_SIMPLE,
+		ScoringRule:        SCORE_TERRITORY,
+		TaxRule:            TAX_ALL,
+		MultiStoneSuicide:  false,
+		HasButton:          false,
+		WhiteHandicapBonus: WHB_ZERO,
+		FriendlyPassOk:     false,
+		Komi:               6.5,
+	}},
+	{"AGA Button", []string{"agabutton"}, Rules{
+		KoRule:             KO_SITUATIONAL,
+		ScoringRule:        SCORE_AREA,
+		TaxRule:            TAX_NONE,
+		MultiStoneSuicide:  false,
+		HasButton:          true,
+		WhiteHandicapBonus: WHB_N_MINUS_ONE,
+		FriendlyPassOk:     true,
+		Komi:               7.0,
+	}},
+	{"AGA", []string{"aga", "bga", "french"}, Rules{
+		KoRule:             KO_SITUATIONAL,
+		ScoringRule:        SCORE_AREA,
+		TaxRule:            TAX_NONE,
+		MultiStoneSuicide:  false,
+		HasButton:          false,
+		WhiteHandicapBonus: WHB_N_MINUS_ONE,
+		FriendlyPassOk:     true,
+		Komi:               7.5,
+	}},
+	{"New Zealand", []string{"newzealand", "nz"}, Rules{
+		KoRule:             KO_SITUATIONAL,
+		ScoringRule:        SCORE_AREA,
+		TaxRule:            TAX_NONE,
+		MultiStoneSuicide:  true,
+		HasButton:          false,
+		WhiteHandicapBonus: WHB_ZERO,
+		FriendlyPassOk:     true,
+		Komi:               7.5,
+	}},
+	{"Ing", []string{"goe", "ing"}, Rules{
+		KoRule:             KO_POSITIONAL,
+		ScoringRule:        SCORE_AREA,
+		TaxRule:            TAX_NONE,
+		MultiStoneSuicide:  true,
+		HasButton:          false,
+		WhiteHandicapBonus: WHB_ZERO,
+		FriendlyPassOk:     true,
+		Komi:               7.5,
+	}},
+	{"Tromp-Taylor", []string{"tromptaylor"}, *new(Rules).GetTrompTaylorish()},
+}
+
 // Original:  https://github.com/lightvector/KataGo/blob/4dfed3ebc9dd289f52c5cb81de45bfd40af8478d/cpp/game/rules.cpp#L257
 // Creates a Rules object from a ruleset string. If none provided or not a valid
 // rule set, will simply return TrompTaylorish Rules. Note that this differs from
@@ -350,128 +1013,235 @@ func (r *Rules) UpdateRules(k, v string) (*Rules, error) {
 // find out the rules. WIP
 // TODO: Complete this mess as time allows
 func (r *Rules) parseRulesHelper(s string) *Rules {
+	if preset := matchPreset(s); preset != nil {
+		rules := preset.rules
+		return &rules
+	}
+	parseLogf("rules %q matched no preset, falling back to Tromp-Taylor", s)
+	return r.GetTrompTaylorish()
+}
+
+// Finds the preset named by s, ignoring case, spaces, dashes and underscores.
+// Returns nil if no preset matches.
+func matchPreset(s string) *rulesPreset {
+	orig := s
 	s = strings.TrimSpace(s)
 	s = strings.ReplaceAll(s, "-", "")
 	s = strings.ReplaceAll(s, "_", "")
 	s = strings.ReplaceAll(s, " ", "")
 	s = strings.ToLower(s)
-	if s == "japanese" || s == "korean" {
-		return &Rules{
-			KoRule:             KO_SIMPLE,
-			ScoringRule:        SCORE_TERRITORY,
-			TaxRule:            TAX_SEKI,
-			MultiStoneSuicide:  false,
-			HasButton:          false,
-			WhiteHandicapBonus: WHB_ZERO,
-			FriendlyPassOk:     false,
-			Komi:               6.5,
+	for i, preset := range rulesPresets {
+		for _, alias := range preset.aliases {
+			if s == alias {
+				parseLogf("rules %q matched preset %s", orig, preset.name)
+				return &rulesPresets[i]
+			}
 		}
 	}
-	if s == "chinese" {
-		return &Rules{
-			KoRule:             KO_SIMPLE,
-			ScoringRule:        SCORE_AREA,
-			TaxRule:            TAX_NONE,
-			MultiStoneSuicide:  false,
-			HasButton:          false,
-			WhiteHandicapBonus: WHB_N,
-			FriendlyPassOk:     false,
-			Komi:               7.5,
-		}
+	return nil
+}
+
+// Like ParseRules, but also returns the name of the preset that matched and
+// whether one did, so callers can confirm how a name was interpreted. Names
+// that match no preset return TrompTaylorish rules, an empty name and false.
+func ParseRulesNamed(s string) (*Rules, string, bool) {
+	preset := matchPreset(s)
+	if preset == nil {
+		parseLogf("rules %q matched no preset, falling back to Tromp-Taylor", s)
+		return new(Rules).GetTrompTaylorish(), "", false
 	}
-	if s == "chineseogs" || s == "chinesekgs" {
-		return &Rules{
-			KoRule:             KO_POSITIONAL,
-			ScoringRule:        SCORE_AREA,
-			TaxRule:            TAX_NONE,
-			MultiStoneSuicide:  false,
-			HasButton:          false,
-			WhiteHandicapBonus: WHB_N,
-			FriendlyPassOk:     true,
-			Komi:               7.5,
+	rules := preset.rules
+	return &rules, preset.name, true
+}
+
+// Returns the name of the preset these rules match, ignoring komi, or an empty
+// string for a custom ruleset.
+func (r *Rules) RulesetName() string {
+	for _, preset := range rulesPresets {
+		if r.EqualsIgnoringKomi(&preset.rules) {
+			return preset.name
 		}
 	}
-	if s == "ancientarea" || s == "stonescoring" {
-		return &Rules{
-			KoRule:             KO_SIMPLE,
-			ScoringRule:        SCORE_AREA,
-			TaxRule:            TAX_ALL,
-			MultiStoneSuicide:  false,
-			HasButton:          false,
-			WhiteHandicapBonus: WHB_ZERO,
-			FriendlyPassOk:     true,
-			Komi:               7.5,
-		}
+	return ""
+}
+
+// Describes the rules in a sentence for tooltips, such as "Chinese rules: area
+// scoring, simple ko, White gets N handicap points, 7.5 komi."
+func (r *Rules) Describe() string {
+	name := r.RulesetName()
+	if name == "" {
+		name = "Custom"
 	}
-	if s == "ancientterritory" {
-		return &Rules{
-			KoRule:             KO_SIMPLE,
-			ScoringRule:        SCORE_TERRITORY,
-			TaxRule:            TAX_ALL,
-			MultiStoneSuicide:  false,
-			HasButton:          false,
-			WhiteHandicapBonus: WHB_ZERO,
-			FriendlyPassOk:     false,
-			Komi:               6.5,
-		}
+	var parts []string
+	if r.IsAreaScoring() {
+		parts = append(parts, "area scoring")
+	} else {
+		parts = append(parts, "territory scoring")
 	}
-	if s == "agabutton" {
-		return &Rules{
-			KoRule:             KO_SITUATIONAL,
-			ScoringRule:        SCORE_AREA,
-			TaxRule:            TAX_NONE,
-			MultiStoneSuicide:  false,
-			HasButton:          true,
-			WhiteHandicapBonus: WHB_N_MINUS_ONE,
-			FriendlyPassOk:     true,
-			Komi:               7.0,
-		}
+	switch r.KoRule {
+	case KO_SIMPLE:
+		parts = append(parts, "simple ko")
+	case KO_POSITIONAL:
+		parts = append(parts, "positional superko")
+	case KO_SITUATIONAL:
+		parts = append(parts, "situational superko")
+	case KO_SPIGHT:
+		parts = append(parts, "Spight ko")
 	}
-	if s == "aga" || s == "bga" || s == "french" {
-		return &Rules{
-			KoRule:             KO_SITUATIONAL,
-			ScoringRule:        SCORE_AREA,
-			TaxRule:            TAX_NONE,
-			MultiStoneSuicide:  false,
-			HasButton:          false,
-			WhiteHandicapBonus: WHB_N_MINUS_ONE,
-			FriendlyPassOk:     true,
-			Komi:               7.5,
-		}
+	switch r.TaxRule {
+	case TAX_SEKI:
+		parts = append(parts, "no points in seki")
+	case TAX_ALL:
+		parts = append(parts, "two point tax per group")
 	}
-	if s == "newzealand" || s == "nz" {
-		return &Rules{
-			KoRule:             KO_SITUATIONAL,
-			ScoringRule:        SCORE_AREA,
-			TaxRule:            TAX_NONE,
-			MultiStoneSuicide:  true,
-			HasButton:          false,
-			WhiteHandicapBonus: WHB_ZERO,
-			FriendlyPassOk:     true,
-			Komi:               7.5,
-		}
+	if r.MultiStoneSuicide {
+		parts = append(parts, "multi-stone suicide allowed")
 	}
-	if s == "goe" || s == "ing" {
-		return &Rules{
-			KoRule:             KO_POSITIONAL,
-			ScoringRule:        SCORE_AREA,
-			TaxRule:            TAX_NONE,
-			MultiStoneSuicide:  true,
-			HasButton:          false,
-			WhiteHandicapBonus: WHB_ZERO,
-			FriendlyPassOk:     true,
-			Komi:               7.5,
-		}
+	if r.HasButton {
+		parts = append(parts, "half point button")
 	}
-	return r.GetTrompTaylorish()
+	switch r.WhiteHandicapBonus {
+	case WHB_N:
+		parts = append(parts, "White gets N handicap points")
+	case WHB_N_MINUS_ONE:
+		parts = append(parts, "White gets N-1 handicap points")
+	}
+	parts = append(parts, formatKomi(r.Komi)+" komi")
+	if r.FriendlyPassOk {
+		parts = append(parts, "friendly passing allowed")
+	}
+	return name + " rules: " + strings.Join(parts, ", ") + "."
 }
 
 func (r *Rules) ParseRules(s string) *Rules {
 	return r.parseRulesHelper(s)
 }
 
+// Parses the named ruleset and overrides its komi without validating it.
+//
+// Deprecated: despite its name this sets komi. Use ParseRulesWithKomi, which
+// also validates it.
 func (r *Rules) ParseRulesWithoutKomi(s string, komi float32) *Rules {
 	rules := r.parseRulesHelper(s)
 	rules.Komi = komi
 	return rules
 }
+
+// Parses the named ruleset and overrides its komi. Returns an error if komi is
+// not an integer or half integer between MIN_USER_KOMI and MAX_USER_KOMI.
+func (r *Rules) ParseRulesWithKomi(s string, komi float32) (*Rules, error) {
+	if err := validateKomi(komi); err != nil {
+		return nil, err
+	}
+	rules := r.parseRulesHelper(s)
+	rules.Komi = komi
+	return rules, nil
+}
+
+func boolToCompact(b bool) string {
+	if b {
+		return "1"
+	}
+	return "0"
+}
+
+// Writes the rules in the compact encoding of KataGo's Rules::toString, such as
+// "koSIMPLEscoreAREAtaxNONEsui0whbNkomi7.5". Like KataGo, button, whb and fpok
+// are only written when they differ from their defaults. The result can be read
+// back with ParseCompactRules.
+func (r *Rules) ToCompactString() string {
+	var sb strings.Builder
+	sb.WriteString("ko")
+	sb.WriteString(writeKoRule(r.KoRule))
+	sb.WriteString("score")
+	sb.WriteString(writeScoringRule(r.ScoringRule))
+	sb.WriteString("tax")
+	sb.WriteString(writeTaxRule(r.TaxRule))
+	sb.WriteString("sui")
+	sb.WriteString(boolToCompact(r.MultiStoneSuicide))
+	if r.HasButton {
+		sb.WriteString("button")
+		sb.WriteString(boolToCompact(r.HasButton))
+	}
+	if r.WhiteHandicapBonus != WHB_ZERO {
+		sb.WriteString("whb")
+		sb.WriteString(writeWhiteHandicapBonus(r.WhiteHandicapBonus))
+	}
+	if r.FriendlyPassOk {
+		sb.WriteString("fpok")
+		sb.WriteString(boolToCompact(r.FriendlyPassOk))
+	}
+	sb.WriteString("komi")
+	sb.WriteString(formatKomi(r.Komi))
+	return sb.String()
+}
+
+// Reads the first of names that prefixes s, returning the parsed value and the
+// rest of s.
+func consumeCompactEnum(s, key string, names []string, parse func(string) (int, error)) (int, string, error) {
+	for _, name := range names {
+		if strings.HasPrefix(s, name) {
+			v, err := parse(name)
+			return v, s[len(name):], err
+		}
+	}
+	return -1, s, fmt.Errorf("invalid value for %s in rules string at %q", key, s)
+}
+
+// Reads a boolean written as 1 or 0, or as true or false, returning it and the
+// rest of s.
+func consumeCompactBool(s, key string) (bool, string, error) {
+	for _, name := range []string{"1", "0", "true", "false"} {
+		if strings.HasPrefix(s, name) {
+			return name == "1" || name == "true", s[len(name):], nil
+		}
+	}
+	return false, s, fmt.Errorf("invalid value for %s in rules string at %q", key, s)
+}
+
+// Creates a Rules object from KataGo's compact encoding without delimiters,
+// such as "koSIMPLEscoreAREAtaxNONEsui0whbNkomi7.5", which KataGo writes to
+// SGF RU[] properties and its logs. Keys may appear in any order, and keys
+// that are missing keep their TrompTaylorish values.
+func ParseCompactRules(s string) (*Rules, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return nil, errors.New("empty rules string")
+	}
+	rules := new(Rules).GetTrompTaylorish()
+	for s != "" {
+		var err error
+		switch {
+		// komi must be checked before ko, which is a prefix of it
+		case strings.HasPrefix(s, "komi"):
+			s = s[len("komi"):]
+			n := strings.IndexFunc(s, func(c rune) bool { return !strings.ContainsRune("+-.0123456789", c) })
+			if n < 0 {
+				n = len(s)
+			}
+			rules.Komi, err = ParseKomi(s[:n])
+			s = s[n:]
+		case strings.HasPrefix(s, "ko"):
+			rules.KoRule, s, err = consumeCompactEnum(s[len("ko"):], "ko", koRuleStrings(), parseKoRule)
+		case strings.HasPrefix(s, "score"):
+			rules.ScoringRule, s, err = consumeCompactEnum(s[len("score"):], "score", scoringRuleStrings(), parseScoringRule)
+		case strings.HasPrefix(s, "tax"):
+			rules.TaxRule, s, err = consumeCompactEnum(s[len("tax"):], "tax", taxRuleStrings(), parseTaxRule)
+		case strings.HasPrefix(s, "whb"):
+			// N-1 must be checked before N
+			rules.WhiteHandicapBonus, s, err = consumeCompactEnum(s[len("whb"):], "whb", []string{"N-1", "N", "ZERO", "0"}, parseWhiteHandicapBonus)
+		case strings.HasPrefix(s, "sui"):
+			rules.MultiStoneSuicide, s, err = consumeCompactBool(s[len("sui"):], "sui")
+		case strings.HasPrefix(s, "button"):
+			rules.HasButton, s, err = consumeCompactBool(s[len("button"):], "button")
+		case strings.HasPrefix(s, "fpok"):
+			rules.FriendlyPassOk, s, err = consumeCompactBool(s[len("fpok"):], "fpok")
+		default:
+			return nil, fmt.Errorf("unknown rule key in rules string at %q", s)
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	return rules, nil
+}
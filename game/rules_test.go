@@ -0,0 +1,633 @@
+package game
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/url"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestToJsonKeepsZeroKomi(t *testing.T) {
+	r := new(Rules).GetTrompTaylorish()
+	r.Komi = 0
+	b, err := r.ToJson()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(string(b), `"komi":0.0`) {
+		t.Errorf("ToJson() = %s, want komi key present", b)
+	}
+	if !strings.Contains(string(b), `"whiteHandicapBonus":"0"`) {
+		t.Errorf("ToJson() = %s, want whiteHandicapBonus key present", b)
+	}
+}
+
+func TestSuggestedKomi(t *testing.T) {
+	tests := []struct {
+		name  string
+		rules *Rules
+		want  float32
+	}{
+		{"area", new(Rules).ParseRules("chinese"), 7.5},
+		{"territory", new(Rules).ParseRules("japanese"), 6.5},
+		{"button", new(Rules).ParseRules("aga-button"), 7.0},
+	}
+	for _, tt := range tests {
+		got := tt.rules.SuggestedKomi()
+		if !slices.Contains(got, tt.want) {
+			t.Errorf("%s: SuggestedKomi() = %v, want it to include %v", tt.name, got, tt.want)
+		}
+		if got[0] != tt.rules.DefaultKomi() {
+			t.Errorf("%s: SuggestedKomi()[0] = %v, want DefaultKomi %v", tt.name, got[0], tt.rules.DefaultKomi())
+		}
+	}
+	if got := new(Rules).ParseRules("aga-button").SuggestedKomi(); slices.Contains(got, 7.5) {
+		t.Errorf("button SuggestedKomi() = %v, want no 7.5", got)
+	}
+}
+
+func TestFromJsonNumericEnums(t *testing.T) {
+	named, err := new(Rules).FromJson([]byte(`{"ko":"POSITIONAL","scoring":"AREA","tax":"ALL","whiteHandicapBonus":"N-1","komi":7.5}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	numeric, err := new(Rules).FromJson([]byte(`{"ko":1,"scoring":0,"tax":2,"whiteHandicapBonus":2,"komi":7.5}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if *named != *numeric {
+		t.Errorf("numeric enums gave %v, want %v", numeric, named)
+	}
+	if _, err := new(Rules).FromJson([]byte(`{"ko":4}`)); err == nil {
+		t.Error("FromJson accepted out of range ko 4")
+	}
+}
+
+func TestFromJsonRejectsNull(t *testing.T) {
+	for _, s := range []string{`null`, `[]`, `7.5`} {
+		if _, err := new(Rules).FromJson([]byte(s)); err == nil {
+			t.Errorf("FromJson(%s) succeeded, want error", s)
+		}
+	}
+}
+
+func TestKomiIsIntOrHalfInt(t *testing.T) {
+	tests := []struct {
+		komi float32
+		want bool
+	}{
+		{7.5, true},
+		{7, true},
+		{-0.5, true},
+		{7.25, false},
+		{6.75, false},
+	}
+	for _, tt := range tests {
+		if got := komiIsIntOrHalfInt(tt.komi); got != tt.want {
+			t.Errorf("komiIsIntOrHalfInt(%v) = %v, want %v", tt.komi, got, tt.want)
+		}
+	}
+}
+
+func TestMarshalJSONMatchesKataGo(t *testing.T) {
+	r := new(Rules).ParseRules("japanese")
+	r.Komi = 6
+	b, err := json.Marshal(r)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"friendlyPassOk":true,"hasButton":false,"ko":"SIMPLE","komi":6.0,"scoring":"TERRITORY","suicide":false,"tax":"SEKI","whiteHandicapBonus":"0"}`
+	if string(b) != want {
+		t.Errorf("json.Marshal() = %s, want %s", b, want)
+	}
+	var back Rules
+	if err := json.Unmarshal(b, &back); err != nil {
+		t.Fatal(err)
+	}
+	if back != *r {
+		t.Errorf("round trip gave %v, want %v", back, *r)
+	}
+}
+
+func TestUnmarshalJSONNull(t *testing.T) {
+	v := struct {
+		Rules Rules `json:"rules"`
+	}{Rules: *new(Rules).ParseRules("chinese")}
+	if err := json.Unmarshal([]byte(`{"rules":null}`), &v); err != nil {
+		t.Fatal(err)
+	}
+	if want := new(Rules).ParseRules("chinese"); v.Rules != *want {
+		t.Errorf("null changed rules to %v, want %v", v.Rules, *want)
+	}
+}
+
+func TestParseRulesQuery(t *testing.T) {
+	values, err := url.ParseQuery("ko=SIMPLE&scoring=TERRITORY&tax=SEKI&suicide=false&hasButton=false&whiteHandicapBonus=0&friendlyPassOk=true&komi=6.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := ParseRulesQuery(values)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := new(Rules).ParseRules("japanese"); *got != *want {
+		t.Errorf("ParseRulesQuery() = %v, want %v", got, want)
+	}
+}
+
+func TestParseRulesQueryErrors(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{"ko=SIMPLE&tax=SOME", "tax=SOME"},
+		{"komi=7.25", "komi=7.25"},
+		{"komi=7.5&komi=6.5", "komi"},
+	}
+	for _, tt := range tests {
+		values, err := url.ParseQuery(tt.query)
+		if err != nil {
+			t.Fatal(err)
+		}
+		_, err = ParseRulesQuery(values)
+		if err == nil {
+			t.Errorf("ParseRulesQuery(%q) succeeded, want error", tt.query)
+		} else if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("ParseRulesQuery(%q) error %q does not name %q", tt.query, err, tt.want)
+		}
+	}
+}
+
+func TestHandicapGameKomi(t *testing.T) {
+	areaZero := new(Rules).ParseRules("chinese")
+	areaZero.WhiteHandicapBonus = WHB_ZERO
+	tests := []struct {
+		name  string
+		rules *Rules
+		n     int
+		want  float32
+	}{
+		{"area WHB_N", new(Rules).ParseRules("chinese"), 2, 0.5},
+		{"territory WHB_ZERO", new(Rules).ParseRules("japanese"), 2, 0.5},
+		{"area WHB_ZERO", areaZero, 3, 2.5},
+		{"no handicap", new(Rules).ParseRules("chinese"), 0, 7.5},
+	}
+	for _, tt := range tests {
+		if got := tt.rules.HandicapGameKomi(tt.n); got != tt.want {
+			t.Errorf("%s: HandicapGameKomi(%d) = %v, want %v", tt.name, tt.n, got, tt.want)
+		}
+	}
+}
+
+func TestValidateCombinations(t *testing.T) {
+	ancient := new(Rules).GetSimpleTerritory()
+	ancient.TaxRule = TAX_ALL
+	if err := ancient.Validate(); err != nil {
+		t.Errorf("territory with TAX_ALL: Validate() = %v, want nil", err)
+	}
+	button := new(Rules).GetSimpleTerritory()
+	button.HasButton = true
+	if err := button.Validate(); err == nil {
+		t.Error("territory with button: Validate() = nil, want error")
+	}
+}
+
+func TestRulesPrintByName(t *testing.T) {
+	r := new(Rules).ParseRules("chinese")
+	for _, got := range []string{fmt.Sprint(r), fmt.Sprint(*r), fmt.Sprintf("%v", r)} {
+		for _, want := range []string{"Ko Rule: SIMPLE", "Scoring Rule: AREA", "Tax Rule: NONE", "Komi: 7.5"} {
+			if !strings.Contains(got, want) {
+				t.Errorf("fmt.Sprint() = %q, want it to contain %q", got, want)
+			}
+		}
+	}
+}
+
+func TestParseCompactRules(t *testing.T) {
+	japanese := new(Rules).ParseRules("japanese")
+	chinese := new(Rules).ParseRules("chinese")
+	aga := new(Rules).ParseRules("aga")
+	aga.Komi = 7
+	tests := []struct {
+		s    string
+		want *Rules
+	}{
+		{"koSIMPLEscoreTERRITORYtaxSEKIsui0fpok1komi6.5", japanese},
+		{"koSIMPLEscoreAREAtaxNONEsui0whbNkomi7.5", chinese},
+		// komi first, so it must not be read as ko
+		{"komi7.5koSIMPLEscoreAREAtaxNONEsui0whbN", chinese},
+		// N-1 followed by komi, so N-1 must not be read as N
+		{"koSITUATIONALscoreAREAtaxNONEsui0whbN-1fpok1komi7", aga},
+	}
+	for _, tt := range tests {
+		got, err := ParseCompactRules(tt.s)
+		if err != nil {
+			t.Errorf("ParseCompactRules(%q) error: %v", tt.s, err)
+			continue
+		}
+		if *got != *tt.want {
+			t.Errorf("ParseCompactRules(%q) = %v, want %v", tt.s, got, tt.want)
+		}
+	}
+	for _, s := range []string{"", "korean", "koSIMPLEscoreAREAtaxNONEbogus1"} {
+		if _, err := ParseCompactRules(s); err == nil {
+			t.Errorf("ParseCompactRules(%q) succeeded, want error", s)
+		}
+	}
+}
+
+func TestToCompactString(t *testing.T) {
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"japanese", "koSIMPLEscoreTERRITORYtaxSEKIsui0fpok1komi6.5"},
+		{"chinese", "koSIMPLEscoreAREAtaxNONEsui0whbNkomi7.5"},
+	}
+	for _, tt := range tests {
+		if got := new(Rules).ParseRules(tt.name).ToCompactString(); got != tt.want {
+			t.Errorf("%s: ToCompactString() = %q, want %q", tt.name, got, tt.want)
+		}
+	}
+	for _, preset := range rulesPresets {
+		s := preset.rules.ToCompactString()
+		got, err := ParseCompactRules(s)
+		if err != nil {
+			t.Errorf("%s: ParseCompactRules(%q) error: %v", preset.name, s, err)
+			continue
+		}
+		if *got != preset.rules {
+			t.Errorf("%s: round trip of %q gave %v, want %v", preset.name, s, got, preset.rules)
+		}
+	}
+}
+
+func TestParseRulesWithKomi(t *testing.T) {
+	r, err := new(Rules).ParseRulesWithKomi("japanese", 5.5)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := new(Rules).ParseRules("japanese")
+	want.Komi = 5.5
+	if *r != *want {
+		t.Errorf("ParseRulesWithKomi() = %v, want %v", r, want)
+	}
+	for _, komi := range []float32{7.25, 151} {
+		if _, err := new(Rules).ParseRulesWithKomi("japanese", komi); err == nil {
+			t.Errorf("ParseRulesWithKomi(%v) succeeded, want error", komi)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	got := new(Rules).ParseRules("chinese").Describe()
+	for _, want := range []string{"Chinese rules", "area", "7.5"} {
+		if !strings.Contains(got, want) {
+			t.Errorf("Describe() = %q, want it to mention %q", got, want)
+		}
+	}
+	custom := new(Rules).ParseRules("chinese")
+	custom.KoRule = KO_SPIGHT
+	if got := custom.Describe(); !strings.HasPrefix(got, "Custom rules:") {
+		t.Errorf("Describe() = %q, want custom rules", got)
+	}
+}
+
+func TestUpdateRulesLenient(t *testing.T) {
+	r := new(Rules).GetTrompTaylorish()
+	ok, err := r.UpdateRulesLenient("futureRule", "1")
+	if ok || err != nil {
+		t.Errorf("UpdateRulesLenient(unknown) = %v, %v, want false, nil", ok, err)
+	}
+	if *r != *new(Rules).GetTrompTaylorish() {
+		t.Errorf("unknown key changed rules to %v", r)
+	}
+	ok, err = r.UpdateRulesLenient("ko", "SOMETIMES")
+	if !ok || err == nil {
+		t.Errorf("UpdateRulesLenient(bad ko) = %v, %v, want true and an error", ok, err)
+	}
+	ok, err = r.UpdateRulesLenient("komi", "6.5")
+	if !ok || err != nil || r.Komi != 6.5 {
+		t.Errorf("UpdateRulesLenient(komi) = %v, %v with komi %v, want true, nil, 6.5", ok, err, r.Komi)
+	}
+	if _, err := r.UpdateRules("futureRule", "1"); !errors.Is(err, ErrUnknownRuleKey) {
+		t.Errorf("UpdateRules(unknown) error %v is not ErrUnknownRuleKey", err)
+	}
+}
+
+func TestParseRulesDSL(t *testing.T) {
+	got, err := ParseRulesDSL("chinese, komi=6.5, ko=POSITIONAL")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := new(Rules).ParseRules("chinese")
+	want.Komi = 6.5
+	want.KoRule = KO_POSITIONAL
+	if *got != *want {
+		t.Errorf("ParseRulesDSL() = %v, want %v", got, want)
+	}
+	for _, s := range []string{"chinse,komi=7.5", "chinese,komi7.5", "komi=7.5", "chinese,komi=7.25", ""} {
+		if _, err := ParseRulesDSL(s); err == nil {
+			t.Errorf("ParseRulesDSL(%q) succeeded, want error", s)
+		}
+	}
+}
+
+func TestParseSGFKomi(t *testing.T) {
+	r := new(Rules).ParseRules("japanese")
+	tests := []struct {
+		km   string
+		want float32
+	}{
+		{"7", 7},
+		{"7.5", 7.5},
+		{"6.50", 6.5},
+		{" 0.5 ", 0.5},
+		{"-3.5", -3.5},
+		{"", 6.5},
+	}
+	for _, tt := range tests {
+		got, err := r.ParseSGFKomi(tt.km)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseSGFKomi(%q) = %v, %v, want %v", tt.km, got, err, tt.want)
+		}
+	}
+	for _, km := range []string{"7.25", "six", "375"} {
+		if _, err := r.ParseSGFKomi(km); err == nil {
+			t.Errorf("ParseSGFKomi(%q) succeeded, want error", km)
+		}
+	}
+}
+
+func TestAsStringMapRoundTrip(t *testing.T) {
+	for _, preset := range rulesPresets {
+		got, err := new(Rules).GetTrompTaylorish().UpdateRulesMap(preset.rules.AsStringMap())
+		if err != nil {
+			t.Errorf("%s: UpdateRulesMap() error: %v", preset.name, err)
+			continue
+		}
+		if *got != preset.rules {
+			t.Errorf("%s: map round trip gave %v, want %v", preset.name, got, preset.rules)
+		}
+	}
+}
+
+func TestEqualsWithin(t *testing.T) {
+	a := new(Rules).ParseRules("chinese")
+	b := *a
+	b.Komi = math.Nextafter32(a.Komi, 8)
+	if a.Equals(&b) {
+		t.Fatal("Equals() = true for komi differing in the last bit")
+	}
+	if !a.EqualsWithin(&b, 1e-6) {
+		t.Errorf("EqualsWithin(%v, 1e-6) = false for komi %v", b.Komi, a.Komi)
+	}
+	if a.EqualsWithin(&b, 1e-7) {
+		t.Errorf("EqualsWithin(%v, 1e-7) = true for komi %v", b.Komi, a.Komi)
+	}
+	b.Komi = 6.5
+	if a.EqualsWithin(&b, 1e-6) {
+		t.Error("EqualsWithin(6.5, 1e-6) = true for komi 7.5")
+	}
+	b = *a
+	b.KoRule = KO_POSITIONAL
+	if a.EqualsWithin(&b, 1) {
+		t.Error("EqualsWithin() = true for a different ko rule")
+	}
+}
+
+func TestParseRulesNamed(t *testing.T) {
+	r, name, ok := ParseRulesNamed("Chinese OGS")
+	if !ok || name != "Chinese (OGS/KGS)" {
+		t.Errorf("ParseRulesNamed(\"Chinese OGS\") = %q, %v, want Chinese (OGS/KGS), true", name, ok)
+	}
+	if r.RulesetName() != name {
+		t.Errorf("RulesetName() = %q, want %q", r.RulesetName(), name)
+	}
+	r, name, ok = ParseRulesNamed("chinse")
+	if ok || name != "" {
+		t.Errorf("ParseRulesNamed(\"chinse\") = %q, %v, want \"\", false", name, ok)
+	}
+	if *r != *new(Rules).GetTrompTaylorish() {
+		t.Errorf("ParseRulesNamed(\"chinse\") = %v, want Tromp-Taylor", r)
+	}
+}
+
+func TestValidateForBoard(t *testing.T) {
+	r := new(Rules).ParseRules("japanese")
+	if err := r.ValidateForBoard(19, 19); err != nil {
+		t.Errorf("ValidateForBoard(19, 19) = %v, want nil", err)
+	}
+	if err := r.ValidateForBoard(9, 13); err != nil {
+		t.Errorf("ValidateForBoard(9, 13) = %v, want nil", err)
+	}
+	for _, size := range [][2]int{{25, 25}, {20, 19}, {19, 20}, {1, 1}} {
+		if err := r.ValidateForBoard(size[0], size[1]); err == nil {
+			t.Errorf("ValidateForBoard(%d, %d) = nil, want error", size[0], size[1])
+		}
+	}
+	if err := r.ValidateForBoardMaxLen(25, 25, MAX_COMPILED_BOARD_LEN); err != nil {
+		t.Errorf("ValidateForBoardMaxLen(25, 25, %d) = %v, want nil", MAX_COMPILED_BOARD_LEN, err)
+	}
+	if err := r.ValidateForBoardMaxLen(30, 30, MAX_COMPILED_BOARD_LEN); err == nil {
+		t.Errorf("ValidateForBoardMaxLen(30, 30, %d) = nil, want error", MAX_COMPILED_BOARD_LEN)
+	}
+	if err := r.ValidateForBoardMaxLen(30, 30, 30); err == nil {
+		t.Error("ValidateForBoardMaxLen() accepted a maximum past MAX_COMPILED_BOARD_LEN")
+	}
+}
+
+func TestRulesFromEnv(t *testing.T) {
+	t.Setenv("KATAGOGO_RULES", "japanese")
+	t.Setenv("KATAGOGO_KOMI", "5.5")
+	got, err := RulesFromEnv()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := new(Rules).ParseRules("japanese")
+	want.Komi = 5.5
+	if *got != *want {
+		t.Errorf("RulesFromEnv() = %v, want %v", got, want)
+	}
+}
+
+func TestRulesFromEnvRejectsConflicts(t *testing.T) {
+	t.Setenv("KATAGOGO_RULES", "japanese")
+	t.Setenv("KATAGOGO_HAS_BUTTON", "true")
+	if _, err := RulesFromEnv(); err == nil {
+		t.Error("RulesFromEnv() accepted a button with territory scoring")
+	}
+}
+
+func TestRulesFromEnvUnknownPreset(t *testing.T) {
+	var logged []string
+	ParseLogf = func(format string, args ...any) { logged = append(logged, fmt.Sprintf(format, args...)) }
+	t.Cleanup(func() { ParseLogf = nil })
+	t.Setenv("KATAGOGO_RULES", "chinse")
+	if _, err := RulesFromEnv(); err == nil {
+		t.Error("RulesFromEnv() accepted unknown preset chinse")
+	}
+	for _, line := range logged {
+		if strings.Contains(line, "falling back") {
+			t.Errorf("RulesFromEnv() logged %q but did not fall back", line)
+		}
+	}
+}
+
+func TestAdjustKomiToAvoidDraw(t *testing.T) {
+	tests := []struct {
+		komi   float32
+		button bool
+		want   float32
+	}{
+		{7.5, false, 7.5},
+		{7, false, 7.5},
+		{7, true, 7},
+		{7.5, true, 8},
+		{MAX_USER_KOMI, false, MAX_USER_KOMI - 0.5},
+	}
+	for _, tt := range tests {
+		r := new(Rules).ParseRules("chinese")
+		r.Komi = tt.komi
+		r.HasButton = tt.button
+		if got := r.AdjustKomiToAvoidDraw(); got != tt.want {
+			t.Errorf("komi %v button %v: AdjustKomiToAvoidDraw() = %v, want %v", tt.komi, tt.button, got, tt.want)
+		}
+		if r.Komi != tt.komi {
+			t.Errorf("AdjustKomiToAvoidDraw() changed komi to %v", r.Komi)
+		}
+	}
+}
+
+func TestParseKomi(t *testing.T) {
+	tests := []struct {
+		s    string
+		want float32
+	}{
+		{"7.5", 7.5},
+		{"-6.5", -6.5},
+		{"+6.5", 6.5},
+		{"rev 6.5", -6.5},
+		{"Reverse 3", -3},
+		{"rev0", 0},
+		{"-0", 0},
+		{".5", 0.5},
+	}
+	for _, tt := range tests {
+		got, err := ParseKomi(tt.s)
+		if err != nil || got != tt.want {
+			t.Errorf("ParseKomi(%q) = %v, %v, want %v", tt.s, got, err, tt.want)
+		}
+		if math.Signbit(float64(got)) != math.Signbit(float64(tt.want)) {
+			t.Errorf("ParseKomi(%q) = %v, want sign of %v", tt.s, formatKomi(got), formatKomi(tt.want))
+		}
+	}
+	for _, s := range []string{"rev 160", "rev -6.5", "rev +6.5", "0x1p3", "Inf", "NaN", "1e1", "--6.5", "+-6.5", "7.5.", ".", "", "rev", "7.25"} {
+		if got, err := ParseKomi(s); err == nil {
+			t.Errorf("ParseKomi(%q) = %v, want error", s, got)
+		}
+	}
+}
+
+func TestKomiInBounds(t *testing.T) {
+	tests := []struct {
+		komi float32
+		want bool
+	}{
+		{149.5, true},
+		{150, true},
+		{-150, true},
+		{150.5, false},
+		{-150.5, false},
+	}
+	for _, tt := range tests {
+		r := new(Rules).GetTrompTaylorish()
+		r.Komi = tt.komi
+		if got := r.KomiInBounds(); got != tt.want {
+			t.Errorf("KomiInBounds() with komi %v = %v, want %v", tt.komi, got, tt.want)
+		}
+	}
+}
+
+func TestKGSJapaneseAliases(t *testing.T) {
+	want := new(Rules).ParseRules("japanese")
+	for _, s := range []string{"kgs-japanese", "japanese_kgs"} {
+		r, name, ok := ParseRulesNamed(s)
+		if !ok || name != "Japanese" {
+			t.Errorf("ParseRulesNamed(%q) = %q, %v, want Japanese, true", s, name, ok)
+		}
+		if *r != *want {
+			t.Errorf("ParseRulesNamed(%q) = %v, want %v", s, r, want)
+		}
+	}
+}
+
+func TestScoringPredicates(t *testing.T) {
+	for _, preset := range rulesPresets {
+		area, territory := preset.rules.IsAreaScoring(), preset.rules.IsTerritoryScoring()
+		if area == territory {
+			t.Errorf("%s: IsAreaScoring() = %v and IsTerritoryScoring() = %v, want exactly one", preset.name, area, territory)
+		}
+		if area != (preset.rules.ScoringRule == SCORE_AREA) {
+			t.Errorf("%s: IsAreaScoring() = %v for scoring rule %s", preset.name, area, writeScoringRule(preset.rules.ScoringRule))
+		}
+	}
+}
+
+func TestMigrateRulesJSON(t *testing.T) {
+	tests := []struct {
+		name string
+		old  string
+		want string
+	}{
+		{
+			"numeric enums without komi",
+			`{"ko":0,"scoring":1,"tax":1,"suicide":false,"hasButton":false,"whiteHandicapBonus":0,"friendlyPassOk":true}`,
+			`{"friendlyPassOk":true,"hasButton":false,"ko":"SIMPLE","komi":0.0,"scoring":"TERRITORY","suicide":false,"tax":"SEKI","whiteHandicapBonus":"0"}`,
+		},
+		{
+			"multiStoneSuicideLegal",
+			`{"ko":"POSITIONAL","scoring":"AREA","tax":"NONE","multiStoneSuicideLegal":true,"komi":7.5}`,
+			`{"friendlyPassOk":false,"hasButton":false,"ko":"POSITIONAL","komi":7.5,"scoring":"AREA","suicide":true,"tax":"NONE","whiteHandicapBonus":"0"}`,
+		},
+	}
+	for _, tt := range tests {
+		got, err := MigrateRulesJSON([]byte(tt.old))
+		if err != nil {
+			t.Errorf("%s: MigrateRulesJSON() error: %v", tt.name, err)
+			continue
+		}
+		if string(got) != tt.want {
+			t.Errorf("%s: MigrateRulesJSON() = %s, want %s", tt.name, got, tt.want)
+		}
+	}
+	for _, old := range []string{`{"suicide":true,"multiStoneSuicideLegal":true}`, `null`, `[]`, `7.5`} {
+		if _, err := MigrateRulesJSON([]byte(old)); err == nil {
+			t.Errorf("MigrateRulesJSON(%s) succeeded, want error", old)
+		}
+	}
+}
+
+func TestParseLogf(t *testing.T) {
+	var logged []string
+	ParseLogf = func(format string, args ...any) { logged = append(logged, fmt.Sprintf(format, args...)) }
+	t.Cleanup(func() { ParseLogf = nil })
+	tests := []struct {
+		name string
+		want string
+	}{
+		{"chinse", "falling back to Tromp-Taylor"},
+		{"chinese", "matched preset Chinese"},
+	}
+	for _, tt := range tests {
+		logged = nil
+		new(Rules).ParseRules(tt.name)
+		if len(logged) != 1 || !strings.Contains(logged[0], tt.want) {
+			t.Errorf("ParseRules(%q) logged %q, want one line containing %q", tt.name, logged, tt.want)
+		}
+	}
+}